@@ -0,0 +1,88 @@
+package structmap
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ToSnakeCase converts a camelCase or PascalCase string to snake_case,
+// e.g. "UserID" becomes "user_id". Dotted path segments (as produced by
+// the `dotted` field option) are converted independently and rejoined
+// with '.', so "Nested.FullName" becomes "nested.full_name".
+func ToSnakeCase(s string) string {
+	return transformSegments(s, "_")
+}
+
+// ToKebabCase converts a camelCase or PascalCase string to kebab-case,
+// e.g. "UserID" becomes "user-id". Dotted path segments are handled the
+// same way as ToSnakeCase.
+func ToKebabCase(s string) string {
+	return transformSegments(s, "-")
+}
+
+// ToCamelCase converts a snake_case or kebab-case string to camelCase,
+// e.g. "user_id" becomes "userId". Dotted path segments are handled the
+// same way as ToSnakeCase.
+func ToCamelCase(s string) string {
+	segments := strings.Split(s, ".")
+	for i, seg := range segments {
+		words := splitWords(seg)
+		for j := 1; j < len(words); j++ {
+			words[j] = strings.Title(words[j])
+		}
+		segments[i] = strings.Join(words, "")
+	}
+	return strings.Join(segments, ".")
+}
+
+// ToLowerCase lowercases s without touching word boundaries.
+func ToLowerCase(s string) string {
+	return strings.ToLower(s)
+}
+
+// transformSegments applies splitWords to each '.'-separated segment of
+// s and rejoins the words in that segment with sep, preserving the dots.
+func transformSegments(s string, sep string) string {
+	segments := strings.Split(s, ".")
+	for i, seg := range segments {
+		segments[i] = strings.Join(splitWords(seg), sep)
+	}
+	return strings.Join(segments, ".")
+}
+
+// splitWords breaks s into lowercase words on underscores, hyphens and
+// camelCase/PascalCase boundaries, so the case transforms above can be
+// implemented as a split followed by a join. Dots are handled by the
+// caller, not here, since they mark dotted path segments rather than
+// word boundaries within a segment.
+func splitWords(s string) []string {
+	var words []string
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, strings.ToLower(current.String()))
+			current.Reset()
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || unicode.IsSpace(r):
+			flush()
+		case unicode.IsUpper(r):
+			startsNewWord := i > 0 && (!unicode.IsUpper(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1])))
+			if startsNewWord {
+				flush()
+			}
+			current.WriteRune(r)
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return words
+}
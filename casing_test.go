@@ -0,0 +1,92 @@
+package structmap
+
+import "testing"
+
+func TestCaseTransforms(t *testing.T) {
+	cases := []struct {
+		in, snake, kebab, camel string
+	}{
+		{"UserID", "user_id", "user-id", "userId"},
+		{"user_id", "user_id", "user-id", "userId"},
+		{"user-name", "user_name", "user-name", "userName"},
+	}
+
+	for _, c := range cases {
+		if got := ToSnakeCase(c.in); got != c.snake {
+			t.Errorf("ToSnakeCase(%q) = %q, want %q", c.in, got, c.snake)
+		}
+		if got := ToKebabCase(c.in); got != c.kebab {
+			t.Errorf("ToKebabCase(%q) = %q, want %q", c.in, got, c.kebab)
+		}
+		if got := ToCamelCase(c.in); got != c.camel {
+			t.Errorf("ToCamelCase(%q) = %q, want %q", c.in, got, c.camel)
+		}
+	}
+
+	if got := ToCamelCase("user_id"); got != "userId" {
+		t.Errorf("ToCamelCase(%q) = %q, want %q", "user_id", got, "userId")
+	}
+	if got := ToLowerCase("UserID"); got != "userid" {
+		t.Errorf("ToLowerCase(%q) = %q, want %q", "UserID", got, "userid")
+	}
+}
+
+type caseTarget struct {
+	UserID string     `map:"UserID"`
+	Nested caseNested `map:"Nested,dotted"`
+}
+
+type caseNested struct {
+	FullName string `map:"FullName"`
+}
+
+func TestStructToMap_WithKeyTransform(t *testing.T) {
+	src := caseTarget{UserID: "1", Nested: caseNested{FullName: "a"}}
+
+	got, err := StructToMap(src, "map", "", WithKeyTransform(ToSnakeCase))
+	if err != nil {
+		t.Fatalf("StructToMap() error = %v", err)
+	}
+
+	if got["user_id"] != "1" {
+		t.Fatalf("user_id = %v", got["user_id"])
+	}
+	if got["nested.full_name"] != "a" {
+		t.Fatalf("nested.full_name = %v, got keys %+v", got["nested.full_name"], got)
+	}
+}
+
+func TestStructToMap_WithKeyTransform_AppliedOncePerKey(t *testing.T) {
+	src := caseTarget{UserID: "1", Nested: caseNested{FullName: "a"}}
+
+	calls := 0
+	transform := func(s string) string {
+		calls++
+		return s + "_X"
+	}
+
+	got, err := StructToMap(src, "map", "", WithKeyTransform(transform))
+	if err != nil {
+		t.Fatalf("StructToMap() error = %v", err)
+	}
+
+	if got["Nested.FullName_X"] != "a" {
+		t.Fatalf("Nested.FullName_X = %v, got keys %+v", got["Nested.FullName_X"], got)
+	}
+	if calls != 2 {
+		t.Fatalf("transform called %d times, want 2 (one per produced key)", calls)
+	}
+}
+
+func TestStructToMap_WithKeyPrefix(t *testing.T) {
+	src := caseTarget{UserID: "1"}
+
+	got, err := StructToMap(src, "map", "", WithKeyPrefix("api_"))
+	if err != nil {
+		t.Fatalf("StructToMap() error = %v", err)
+	}
+
+	if got["api_UserID"] != "1" {
+		t.Fatalf("api_UserID = %v, got keys %+v", got["api_UserID"], got)
+	}
+}
@@ -0,0 +1,357 @@
+package structmap
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DecodeHookFunc converts data (as decoded from the source map) from its
+// natural type to the destination field's type. Returning a nil
+// interface{} leaves data unchanged so the next hook, or the default
+// conversion, can take over. It is the decode-side counterpart of
+// EncodeHookFunc, used for types like time.Time (from an RFC3339
+// string) or net.IP (from a string).
+type DecodeHookFunc func(from, to reflect.Type, data interface{}) (interface{}, error)
+
+// MapToStruct decodes src into dst, which must be a non-nil pointer to a
+// struct. It mirrors the tag conventions used by StructToMap: tag picks
+// the tag name to read keys from, and the `dive`/`dotted` field options
+// control how nested struct fields are located, so a flat map with
+// `b.c` keys can be routed into a nested `B.C` field and a dived struct
+// reads its fields straight from the parent map.
+//
+// Fields tagged `omitempty` are skipped when their key is missing
+// instead of raising an error. Passing WeaklyTypedInput enables
+// best-effort conversions such as string<->number and string<->bool.
+// DecodeHookFunc values registered with WithDecodeHook run before the
+// default conversion, letting callers decode custom types such as
+// time.Time or net.IP. Every field error is collected and returned
+// together as a *MultiError instead of stopping at the first one.
+func MapToStruct(src MappedStruct, dst interface{}, tag string, opts ...Option) error {
+	o := applyOptions(opts)
+
+	reflectedValue := reflect.ValueOf(dst)
+	if reflectedValue.Kind() != reflect.Pointer || reflectedValue.IsNil() {
+		return fmt.Errorf("dst must be a non-nil pointer to a struct")
+	}
+
+	reflectedValue = reflectedValue.Elem()
+	if reflectedValue.Kind() != reflect.Struct {
+		return fmt.Errorf("dst is not a struct but %s", reflectedValue.Kind().String())
+	}
+
+	return decodeStruct(src, reflectedValue, tag, o)
+}
+
+func decodeStruct(src MappedStruct, dstValue reflect.Value, tag string, o *Options) error {
+	var merr MultiError
+
+	dstType := dstValue.Type()
+	for i := 0; i < dstType.NumField(); i++ {
+		fieldType := dstType.Field(i)
+
+		// ignore unexported field
+		if fieldType.PkgPath != "" {
+			continue
+		}
+
+		tagVal, flag := tagsReader(fieldType, tag)
+		if flag&FLAG_IGNORE != 0 {
+			continue
+		}
+
+		fieldValue := dstValue.Field(i)
+
+		if flag&(FLAG_DIVE|FLAG_DOTTED) != 0 && fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		if flag&FLAG_DIVE != 0 && fieldValue.Kind() == reflect.Struct {
+			if err := decodeStruct(src, fieldValue, tag, o); err != nil {
+				merr.add(err)
+			}
+			continue
+		}
+		if flag&FLAG_DOTTED != 0 && fieldValue.Kind() == reflect.Struct {
+			if err := decodeStruct(dottedSubmap(src, tagVal), fieldValue, tag, o); err != nil {
+				merr.add(err)
+			}
+			continue
+		}
+
+		raw, ok := src[tagVal]
+		if !ok {
+			if flag&FLAG_OMITEMPTY == 0 {
+				merr.add(fmt.Errorf("field %q: key %q not found in source map", fieldType.Name, tagVal))
+			}
+			continue
+		}
+
+		if err := decodeValue(raw, fieldValue, tag, o); err != nil {
+			merr.add(fmt.Errorf("field %q: %w", fieldType.Name, err))
+		}
+	}
+
+	return merr.orNil()
+}
+
+// dottedSubmap extracts every key of src prefixed with "prefix." into a
+// new map with that prefix stripped, so a dotted struct field can be
+// decoded as if it were reading its own flat map.
+func dottedSubmap(src MappedStruct, prefix string) MappedStruct {
+	sub := make(MappedStruct)
+	p := prefix + "."
+	for k, v := range src {
+		if strings.HasPrefix(k, p) {
+			sub[strings.TrimPrefix(k, p)] = v
+		}
+	}
+	return sub
+}
+
+func decodeValue(raw interface{}, dstValue reflect.Value, tag string, o *Options) error {
+	if raw != nil {
+		rawValue := reflect.ValueOf(raw)
+		for _, hook := range o.decodeHooks {
+			converted, err := hook(rawValue.Type(), dstValue.Type(), raw)
+			if err != nil {
+				return err
+			}
+			if converted != nil {
+				raw = converted
+				rawValue = reflect.ValueOf(raw)
+			}
+		}
+	}
+
+	if dstValue.Kind() == reflect.Pointer {
+		if raw == nil {
+			return nil
+		}
+		if dstValue.IsNil() {
+			dstValue.Set(reflect.New(dstValue.Type().Elem()))
+		}
+		return decodeValue(raw, dstValue.Elem(), tag, o)
+	}
+
+	if raw == nil {
+		return nil
+	}
+
+	// A hook (or the source map) may already hand back a value of the
+	// exact destination type, e.g. a decode hook turning an RFC3339
+	// string into a time.Time. Take that as-is before falling back to
+	// treating a struct destination as a nested map to decode into.
+	rawValue := reflect.ValueOf(raw)
+	if rawValue.Type().AssignableTo(dstValue.Type()) {
+		dstValue.Set(rawValue)
+		return nil
+	}
+
+	if dstValue.Kind() == reflect.Struct {
+		nested, ok := raw.(MappedStruct)
+		if !ok {
+			if m, isMap := raw.(map[string]interface{}); isMap {
+				nested, ok = MappedStruct(m), true
+			}
+		}
+		if !ok {
+			return fmt.Errorf("cannot decode %T into struct", raw)
+		}
+		return decodeStruct(nested, dstValue, tag, o)
+	}
+
+	if dstValue.Kind() == reflect.Slice || dstValue.Kind() == reflect.Array {
+		return decodeSequence(raw, rawValue, dstValue, tag, o)
+	}
+
+	if dstValue.Kind() == reflect.Map {
+		return decodeMap(raw, rawValue, dstValue, tag, o)
+	}
+
+	// Same-Kind conversions (e.g. a named string or int type whose
+	// underlying Kind matches raw's) never change the represented value,
+	// so they're safe in strict mode. Anything that actually widens or
+	// narrows a numeric value, or crosses kinds, only happens when
+	// WeaklyTypedInput is set; without it, such data is rejected rather
+	// than silently truncated.
+	if rawValue.Kind() == dstValue.Kind() && rawValue.Type().ConvertibleTo(dstValue.Type()) {
+		dstValue.Set(rawValue.Convert(dstValue.Type()))
+		return nil
+	}
+
+	if o.weaklyTyped {
+		return decodeWeaklyTyped(raw, dstValue)
+	}
+
+	return fmt.Errorf("cannot decode %T into %s", raw, dstValue.Kind())
+}
+
+// decodeSequence decodes raw, which must be a slice or array (as
+// produced by StructToMap's element-wise slice/array handling), into
+// dstValue, a slice or array field. Each element is decoded
+// independently through decodeValue, so element structs (themselves
+// MappedStruct values) go through the same tag/dive/dotted rules as any
+// other nested struct. An array destination only fills as many elements
+// as it has room for.
+func decodeSequence(raw interface{}, rawValue reflect.Value, dstValue reflect.Value, tag string, o *Options) error {
+	if rawValue.Kind() != reflect.Slice && rawValue.Kind() != reflect.Array {
+		return fmt.Errorf("cannot decode %T into %s", raw, dstValue.Kind())
+	}
+
+	n := rawValue.Len()
+	elemType := dstValue.Type().Elem()
+
+	var out reflect.Value
+	if dstValue.Kind() == reflect.Array {
+		out = reflect.New(dstValue.Type()).Elem()
+		if n > dstValue.Len() {
+			n = dstValue.Len()
+		}
+	} else {
+		out = reflect.MakeSlice(dstValue.Type(), n, n)
+	}
+
+	var merr MultiError
+	for i := 0; i < n; i++ {
+		elem := reflect.New(elemType).Elem()
+		if err := decodeValue(rawValue.Index(i).Interface(), elem, tag, o); err != nil {
+			merr.add(fmt.Errorf("index %d: %w", i, err))
+			continue
+		}
+		out.Index(i).Set(elem)
+	}
+	if err := merr.orNil(); err != nil {
+		return err
+	}
+
+	dstValue.Set(out)
+	return nil
+}
+
+// decodeMap decodes raw, which must be a map with string-like keys
+// (MappedStruct, map[string]MappedStruct or map[string]interface{}, as
+// produced by StructToMap's map handling), into dstValue, a map field.
+// Each value is decoded independently through decodeValue, the same way
+// decodeSequence handles slice elements.
+func decodeMap(raw interface{}, rawValue reflect.Value, dstValue reflect.Value, tag string, o *Options) error {
+	if rawValue.Kind() != reflect.Map {
+		return fmt.Errorf("cannot decode %T into %s", raw, dstValue.Kind())
+	}
+
+	keyType := dstValue.Type().Key()
+	if keyType.Kind() != reflect.String {
+		return fmt.Errorf("cannot decode into map with non-string key type %s", keyType)
+	}
+	elemType := dstValue.Type().Elem()
+
+	out := reflect.MakeMapWithSize(dstValue.Type(), rawValue.Len())
+
+	var merr MultiError
+	iter := rawValue.MapRange()
+	for iter.Next() {
+		key := fmt.Sprint(iter.Key().Interface())
+		elem := reflect.New(elemType).Elem()
+		if err := decodeValue(iter.Value().Interface(), elem, tag, o); err != nil {
+			merr.add(fmt.Errorf("key %q: %w", key, err))
+			continue
+		}
+		out.SetMapIndex(reflect.ValueOf(key).Convert(keyType), elem)
+	}
+	if err := merr.orNil(); err != nil {
+		return err
+	}
+
+	dstValue.Set(out)
+	return nil
+}
+
+func decodeWeaklyTyped(raw interface{}, dstValue reflect.Value) error {
+	switch dstValue.Kind() {
+	case reflect.String:
+		dstValue.SetString(fmt.Sprintf("%v", raw))
+		return nil
+	case reflect.Bool:
+		b, err := weakBool(raw)
+		if err != nil {
+			return err
+		}
+		dstValue.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := weakInt(raw)
+		if err != nil {
+			return err
+		}
+		dstValue.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := weakInt(raw)
+		if err != nil {
+			return err
+		}
+		dstValue.SetUint(uint64(n))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := weakFloat(raw)
+		if err != nil {
+			return err
+		}
+		dstValue.SetFloat(f)
+		return nil
+	default:
+		return fmt.Errorf("cannot weakly decode %T into %s", raw, dstValue.Kind())
+	}
+}
+
+func weakBool(raw interface{}) (bool, error) {
+	switch v := raw.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	default:
+		return false, fmt.Errorf("cannot convert %T to bool", raw)
+	}
+}
+
+func weakInt(raw interface{}) (int64, error) {
+	if s, ok := raw.(string); ok {
+		return strconv.ParseInt(s, 10, 64)
+	}
+
+	rv := reflect.ValueOf(raw)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return int64(rv.Float()), nil
+	default:
+		return 0, fmt.Errorf("cannot convert %T to int", raw)
+	}
+}
+
+func weakFloat(raw interface{}) (float64, error) {
+	if s, ok := raw.(string); ok {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	rv := reflect.ValueOf(raw)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	default:
+		return 0, fmt.Errorf("cannot convert %T to float64", raw)
+	}
+}
@@ -0,0 +1,199 @@
+package structmap
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type decodeTargetB struct {
+	C string `map:"c"`
+}
+
+type decodeTarget struct {
+	AA string        `map:"aa"`
+	B  decodeTargetB `map:"b,dotted"`
+	N  int           `map:"n,omitempty"`
+}
+
+func TestMapToStruct(t *testing.T) {
+	src := MappedStruct{
+		"aa":  "hello",
+		"b.c": "world",
+	}
+
+	var dst decodeTarget
+	if err := MapToStruct(src, &dst, "map"); err != nil {
+		t.Fatalf("MapToStruct() error = %v", err)
+	}
+
+	if dst.AA != "hello" || dst.B.C != "world" {
+		t.Fatalf("unexpected result: %+v", dst)
+	}
+	if dst.N != 0 {
+		t.Fatalf("expected omitempty field to stay zero, got %d", dst.N)
+	}
+}
+
+type decodePtrTarget struct {
+	B *decodeTargetB `map:"b,dotted"`
+}
+
+func TestMapToStruct_DottedPointerField(t *testing.T) {
+	src := MappedStruct{"b.c": "y"}
+
+	var dst decodePtrTarget
+	if err := MapToStruct(src, &dst, "map"); err != nil {
+		t.Fatalf("MapToStruct() error = %v", err)
+	}
+
+	if dst.B == nil || dst.B.C != "y" {
+		t.Fatalf("unexpected result: %+v", dst)
+	}
+}
+
+func TestMapToStruct_MissingRequiredField(t *testing.T) {
+	src := MappedStruct{"b.c": "world"}
+
+	var dst decodeTarget
+	err := MapToStruct(src, &dst, "map")
+	if err == nil {
+		t.Fatal("expected error for missing required key, got nil")
+	}
+
+	merr, ok := err.(*MultiError)
+	if !ok {
+		t.Fatalf("expected *MultiError, got %T", err)
+	}
+	if len(merr.Errors) != 1 {
+		t.Fatalf("expected 1 collected error, got %d: %v", len(merr.Errors), merr.Errors)
+	}
+}
+
+func TestMapToStruct_StrictModeRejectsNumericNarrowing(t *testing.T) {
+	type target struct {
+		Count uint8 `map:"count"`
+	}
+
+	src := MappedStruct{"count": int64(-1)}
+
+	var dst target
+	if err := MapToStruct(src, &dst, "map"); err == nil {
+		t.Fatalf("expected error decoding int64(-1) into uint8 without WeaklyTypedInput, got dst = %+v", dst)
+	}
+}
+
+func TestMapToStruct_StrictModeRejectsLossyFloatToInt(t *testing.T) {
+	type target struct {
+		N int `map:"n"`
+	}
+
+	src := MappedStruct{"n": float64(3.9)}
+
+	var dst target
+	if err := MapToStruct(src, &dst, "map"); err == nil {
+		t.Fatalf("expected error decoding float64(3.9) into int without WeaklyTypedInput, got dst = %+v", dst)
+	}
+}
+
+func TestMapToStruct_WeaklyTypedInput(t *testing.T) {
+	type target struct {
+		Age    int  `map:"age"`
+		Active bool `map:"active"`
+	}
+
+	src := MappedStruct{"age": "42", "active": "true"}
+
+	var dst target
+	if err := MapToStruct(src, &dst, "map", WeaklyTypedInput()); err != nil {
+		t.Fatalf("MapToStruct() error = %v", err)
+	}
+	if dst.Age != 42 || !dst.Active {
+		t.Fatalf("unexpected result: %+v", dst)
+	}
+}
+
+func TestMapToStruct_SliceAndMap(t *testing.T) {
+	type item struct {
+		Name string `map:"name"`
+	}
+	type target struct {
+		Tags  []string        `map:"tags"`
+		Items []item          `map:"items"`
+		Attrs map[string]item `map:"attrs"`
+		Nums  map[string]int  `map:"nums"`
+	}
+
+	src := MappedStruct{
+		"tags":  []interface{}{"a", "b"},
+		"items": []MappedStruct{{"name": "one"}, {"name": "two"}},
+		"attrs": map[string]MappedStruct{"x": {"name": "ex"}},
+		"nums":  map[string]interface{}{"y": 1},
+	}
+
+	var dst target
+	if err := MapToStruct(src, &dst, "map"); err != nil {
+		t.Fatalf("MapToStruct() error = %v", err)
+	}
+
+	if len(dst.Tags) != 2 || dst.Tags[0] != "a" || dst.Tags[1] != "b" {
+		t.Fatalf("Tags = %+v", dst.Tags)
+	}
+	if len(dst.Items) != 2 || dst.Items[0].Name != "one" || dst.Items[1].Name != "two" {
+		t.Fatalf("Items = %+v", dst.Items)
+	}
+	if dst.Attrs["x"].Name != "ex" {
+		t.Fatalf("Attrs = %+v", dst.Attrs)
+	}
+	if dst.Nums["y"] != 1 {
+		t.Fatalf("Nums = %+v", dst.Nums)
+	}
+}
+
+func TestMapToStruct_DecodeHook(t *testing.T) {
+	type target struct {
+		CreatedAt time.Time `map:"created_at"`
+		IP        net.IP    `map:"ip"`
+	}
+
+	timeHook := func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != reflect.TypeOf(time.Time{}) {
+			return nil, nil
+		}
+		s, ok := data.(string)
+		if !ok {
+			return nil, nil
+		}
+		return time.Parse(time.RFC3339, s)
+	}
+	ipHook := func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != reflect.TypeOf(net.IP{}) {
+			return nil, nil
+		}
+		s, ok := data.(string)
+		if !ok {
+			return nil, nil
+		}
+		return net.ParseIP(s), nil
+	}
+
+	src := MappedStruct{
+		"created_at": "2024-01-02T15:04:05Z",
+		"ip":         "127.0.0.1",
+	}
+
+	var dst target
+	err := MapToStruct(src, &dst, "map", WithDecodeHook(timeHook), WithDecodeHook(ipHook))
+	if err != nil {
+		t.Fatalf("MapToStruct() error = %v", err)
+	}
+
+	want, _ := time.Parse(time.RFC3339, "2024-01-02T15:04:05Z")
+	if !dst.CreatedAt.Equal(want) {
+		t.Fatalf("CreatedAt = %v, want %v", dst.CreatedAt, want)
+	}
+	if dst.IP.String() != "127.0.0.1" {
+		t.Fatalf("IP = %v, want 127.0.0.1", dst.IP)
+	}
+}
@@ -0,0 +1,458 @@
+package structmap
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// fieldPlanEntry is the precomputed metadata needed to map a single
+// struct field, so Encoder.Encode never has to re-parse tags after the
+// first call for a given type.
+type fieldPlanEntry struct {
+	index []int
+	key   string
+	flags int
+}
+
+// fieldPlan is the ordered set of fieldPlanEntry for one reflect.Type.
+type fieldPlan struct {
+	entries []fieldPlanEntry
+}
+
+// planCacheKey identifies a cached fieldPlan. A plan only depends on a
+// type's tags for a given (tag, method) pair, never on Options, so every
+// Encoder built with the same tag and method shares the same cached
+// plans regardless of which options it was constructed with.
+type planCacheKey struct {
+	typ    reflect.Type
+	tag    string
+	method string
+}
+
+var planCache sync.Map // map[planCacheKey]*fieldPlan
+
+// Encoder maps structs to MappedStruct using a field plan that is built
+// once per reflect.Type and cached, so hot paths (request logging, SQL
+// WHERE-clause builders using the wildcard option) don't re-reflect the
+// type or re-parse tags on every call.
+type Encoder struct {
+	tag       string
+	method    string
+	opts      *Options
+	typeHooks sync.Map // map[reflect.Type]EncodeHookFunc
+}
+
+// NewEncoder returns an Encoder that maps structs using tag and, if
+// method is non-empty, calls method on eligible fields the same way
+// StructToMap does. See StructToMap for the tag and option semantics.
+// Field plans are cached in a package-level store keyed by (tag,
+// method, type), so constructing a new Encoder for a one-off set of
+// options (as StructToMap does) does not discard plans already built by
+// another Encoder using the same tag and method.
+func NewEncoder(tag, method string, opts ...Option) *Encoder {
+	return &Encoder{
+		tag:    tag,
+		method: method,
+		opts:   applyOptions(opts),
+	}
+}
+
+// planFor returns the cached fieldPlan for t, building it on first use.
+func (e *Encoder) planFor(t reflect.Type) *fieldPlan {
+	key := planCacheKey{typ: t, tag: e.tag, method: e.method}
+	if v, ok := planCache.Load(key); ok {
+		return v.(*fieldPlan)
+	}
+
+	plan := &fieldPlan{}
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+
+		// ignore unexported field
+		if fieldType.PkgPath != "" {
+			continue
+		}
+
+		tagVal, flag := tagsReader(fieldType, e.tag)
+		if flag&FLAG_IGNORE != 0 {
+			continue
+		}
+
+		plan.entries = append(plan.entries, fieldPlanEntry{
+			index: fieldType.Index,
+			key:   tagVal,
+			flags: flag,
+		})
+	}
+
+	actual, _ := planCache.LoadOrStore(key, plan)
+	return actual.(*fieldPlan)
+}
+
+// Encode maps data into a MappedStruct using the encoder's cached field
+// plan for data's type.
+func (e *Encoder) Encode(data interface{}) (MappedStruct, error) {
+	reflectedValue := reflect.ValueOf(data)
+
+	if reflectedValue.Kind() == reflect.Pointer {
+		if reflectedValue.IsNil() {
+			return nil, fmt.Errorf("%s is a nil pointer", reflectedValue.Kind().String())
+		}
+		reflectedValue = reflectedValue.Elem()
+	}
+	if reflectedValue.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("data is not a struct but %s", reflectedValue.Kind().String())
+	}
+
+	result, err := e.encodeStruct(reflectedValue)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.opts.keyPrefix == "" {
+		return result, nil
+	}
+	prefixed := make(MappedStruct, len(result))
+	for k, v := range result {
+		prefixed[e.opts.keyPrefix+k] = v
+	}
+	return prefixed, nil
+}
+
+func (e *Encoder) encodeStruct(reflectedValue reflect.Value) (MappedStruct, error) {
+	result := make(MappedStruct)
+	plan := e.planFor(reflectedValue.Type())
+
+	for _, entry := range plan.entries {
+		fieldValue := reflectedValue.FieldByIndex(entry.index)
+
+		if entry.flags&FLAG_OMITEMPTY != 0 && fieldValue.IsZero() {
+			continue
+		}
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		key, value, err := assignValueWithMethod(fieldValue, e.method)
+		if err != nil {
+			return nil, err
+		}
+		if key != "" {
+			result[e.finalKey(key)] = value
+			continue
+		}
+
+		if hook, ok := lookupTypeHook(&e.typeHooks, fieldValue.Type()); ok {
+			hookedValue, hookErr := hook(fieldValue)
+			if hookErr != nil {
+				return nil, hookErr
+			}
+			result[e.finalKey(entry.key)] = hookedValue
+			continue
+		}
+
+		switch fieldValue.Kind() {
+		case reflect.Slice, reflect.Array:
+			mapped, seqErr := e.encodeSequence(fieldValue)
+			if seqErr != nil {
+				return nil, seqErr
+			}
+			result[e.finalKey(entry.key)] = mapped
+		case reflect.Map:
+			mapped, mapErr := e.encodeMap(fieldValue)
+			if mapErr != nil {
+				return nil, mapErr
+			}
+			result[e.finalKey(entry.key)] = mapped
+		case reflect.Chan:
+			result[e.finalKey(entry.key)] = fieldValue
+		case reflect.Struct:
+			if entry.flags&FLAG_DOTTED != 0 {
+				deepRaw, deepErr := e.encodeStructRaw(fieldValue)
+				if deepErr != nil {
+					return nil, deepErr
+				}
+				for k, v := range deepRaw {
+					result[e.finalKey(entry.key+"."+k)] = v
+				}
+				continue
+			}
+
+			deepRes, deepErr := e.encodeStruct(fieldValue)
+			if deepErr != nil {
+				return nil, deepErr
+			}
+			if entry.flags&FLAG_DIVE != 0 {
+				for k, v := range deepRes {
+					result[k] = v
+				}
+			} else {
+				result[e.finalKey(entry.key)] = deepRes
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			result[e.finalKey(entry.key)] = fieldValue.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			result[e.finalKey(entry.key)] = fieldValue.Uint()
+		case reflect.Float32, reflect.Float64:
+			result[e.finalKey(entry.key)] = fieldValue.Float()
+		case reflect.String:
+			if entry.flags&FLAG_WILDCARD != 0 {
+				result[e.finalKey(entry.key)] = "%" + fieldValue.String() + "%"
+			} else {
+				result[e.finalKey(entry.key)] = fieldValue.String()
+			}
+		case reflect.Bool:
+			result[e.finalKey(entry.key)] = fieldValue.Bool()
+		case reflect.Complex64, reflect.Complex128:
+			result[e.finalKey(entry.key)] = fieldValue.Complex()
+		case reflect.Interface:
+			result[e.finalKey(entry.key)] = fieldValue.Interface()
+		}
+	}
+
+	return result, nil
+}
+
+// encodeStructRaw mirrors encodeStruct but leaves every key untransformed
+// and unprefixed. It exists solely so a `dotted` field can compose its
+// full key path ("parent.child.grandchild") out of raw segments before
+// handing the complete path to finalKey a single time; calling finalKey
+// once per segment (the naive recursive approach) runs the transform
+// more than once over the same produced key. A plain nested struct field
+// (no dive/dotted) is not part of that composition, so it is encoded
+// through the normal, transformed encodeStruct and kept as a value.
+func (e *Encoder) encodeStructRaw(reflectedValue reflect.Value) (MappedStruct, error) {
+	result := make(MappedStruct)
+	plan := e.planFor(reflectedValue.Type())
+
+	for _, entry := range plan.entries {
+		fieldValue := reflectedValue.FieldByIndex(entry.index)
+
+		if entry.flags&FLAG_OMITEMPTY != 0 && fieldValue.IsZero() {
+			continue
+		}
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		key, value, err := assignValueWithMethod(fieldValue, e.method)
+		if err != nil {
+			return nil, err
+		}
+		if key != "" {
+			result[key] = value
+			continue
+		}
+
+		if hook, ok := lookupTypeHook(&e.typeHooks, fieldValue.Type()); ok {
+			hookedValue, hookErr := hook(fieldValue)
+			if hookErr != nil {
+				return nil, hookErr
+			}
+			result[entry.key] = hookedValue
+			continue
+		}
+
+		switch fieldValue.Kind() {
+		case reflect.Slice, reflect.Array:
+			mapped, seqErr := e.encodeSequence(fieldValue)
+			if seqErr != nil {
+				return nil, seqErr
+			}
+			result[entry.key] = mapped
+		case reflect.Map:
+			mapped, mapErr := e.encodeMap(fieldValue)
+			if mapErr != nil {
+				return nil, mapErr
+			}
+			result[entry.key] = mapped
+		case reflect.Chan:
+			result[entry.key] = fieldValue
+		case reflect.Struct:
+			if entry.flags&FLAG_DOTTED != 0 || entry.flags&FLAG_DIVE != 0 {
+				deepRaw, deepErr := e.encodeStructRaw(fieldValue)
+				if deepErr != nil {
+					return nil, deepErr
+				}
+				if entry.flags&FLAG_DIVE != 0 {
+					for k, v := range deepRaw {
+						result[k] = v
+					}
+				} else {
+					for k, v := range deepRaw {
+						result[entry.key+"."+k] = v
+					}
+				}
+				continue
+			}
+
+			deepRes, deepErr := e.encodeStruct(fieldValue)
+			if deepErr != nil {
+				return nil, deepErr
+			}
+			result[entry.key] = deepRes
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			result[entry.key] = fieldValue.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			result[entry.key] = fieldValue.Uint()
+		case reflect.Float32, reflect.Float64:
+			result[entry.key] = fieldValue.Float()
+		case reflect.String:
+			if entry.flags&FLAG_WILDCARD != 0 {
+				result[entry.key] = "%" + fieldValue.String() + "%"
+			} else {
+				result[entry.key] = fieldValue.String()
+			}
+		case reflect.Bool:
+			result[entry.key] = fieldValue.Bool()
+		case reflect.Complex64, reflect.Complex128:
+			result[entry.key] = fieldValue.Complex()
+		case reflect.Interface:
+			result[entry.key] = fieldValue.Interface()
+		}
+	}
+
+	return result, nil
+}
+
+// encodeSequence maps a slice or array field. Elements that are structs
+// (and not handled by a registered type hook) recurse through
+// encodeStruct so the result is a []MappedStruct instead of a raw
+// reflect.Value; every other element kind is copied into a plain
+// []interface{}.
+func (e *Encoder) encodeSequence(v reflect.Value) (interface{}, error) {
+	elemType := v.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Pointer
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	_, hooked := lookupTypeHook(&e.typeHooks, elemType)
+
+	if elemType.Kind() == reflect.Struct && !hooked {
+		out := make([]MappedStruct, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem := v.Index(i)
+			if isPtr {
+				if elem.IsNil() {
+					continue
+				}
+				elem = elem.Elem()
+			}
+			mapped, err := e.encodeStruct(elem)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = mapped
+		}
+		return out, nil
+	}
+
+	out := make([]interface{}, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		val, err := e.encodeElement(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		out[i] = val
+	}
+	return out, nil
+}
+
+// encodeMap maps a map[string]T field. Values that are structs (and not
+// handled by a registered type hook) recurse through encodeStruct so the
+// result is a map[string]MappedStruct; every other value kind is copied
+// into a plain map[string]interface{}.
+func (e *Encoder) encodeMap(v reflect.Value) (interface{}, error) {
+	elemType := v.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Pointer
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	_, hooked := lookupTypeHook(&e.typeHooks, elemType)
+
+	iter := v.MapRange()
+	if elemType.Kind() == reflect.Struct && !hooked {
+		out := make(map[string]MappedStruct, v.Len())
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			elem := iter.Value()
+			if isPtr {
+				if elem.IsNil() {
+					out[key] = nil
+					continue
+				}
+				elem = elem.Elem()
+			}
+			mapped, err := e.encodeStruct(elem)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = mapped
+		}
+		return out, nil
+	}
+
+	out := make(map[string]interface{}, v.Len())
+	for iter.Next() {
+		key := fmt.Sprint(iter.Key().Interface())
+		val, err := e.encodeElement(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		out[key] = val
+	}
+	return out, nil
+}
+
+// encodeElement maps a single slice/array/map element that isn't a bare
+// struct: it dereferences pointers, applies a registered type hook if
+// one matches, and otherwise returns the element's value as-is.
+func (e *Encoder) encodeElement(v reflect.Value) (interface{}, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if hook, ok := lookupTypeHook(&e.typeHooks, v.Type()); ok {
+		return hook(v)
+	}
+	return v.Interface(), nil
+}
+
+// finalKey applies the encoder's configured key transform, if any, to k.
+func (e *Encoder) finalKey(k string) string {
+	if e.opts.keyTransform != nil {
+		return e.opts.keyTransform(k)
+	}
+	return k
+}
+
+// encoderKey identifies a cached default Encoder by the (tag, method)
+// pair StructToMap was called with.
+type encoderKey struct {
+	tag    string
+	method string
+}
+
+var defaultEncoders sync.Map // map[encoderKey]*Encoder
+
+// defaultEncoderFor returns the package-level Encoder for tag and
+// method, creating and caching it on first use.
+func defaultEncoderFor(tag, method string) *Encoder {
+	key := encoderKey{tag, method}
+	if v, ok := defaultEncoders.Load(key); ok {
+		return v.(*Encoder)
+	}
+
+	enc := NewEncoder(tag, method)
+	actual, _ := defaultEncoders.LoadOrStore(key, enc)
+	return actual.(*Encoder)
+}
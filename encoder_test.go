@@ -0,0 +1,107 @@
+package structmap
+
+import (
+	"reflect"
+	"testing"
+)
+
+type benchC struct {
+	D string `map:"d"`
+}
+
+type benchB struct {
+	C benchC `map:"c,dotted"`
+	E string `map:"e,dive"`
+}
+
+type benchA struct {
+	AA string `map:"aa"`
+	B  benchB `map:"b,dotted"`
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	enc := NewEncoder("map", "")
+
+	got, err := enc.Encode(benchA{AA: "aa", B: benchB{C: benchC{D: "d"}}})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := MappedStruct{"aa": "aa", "b.c.d": "d", "b.e": ""}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("Encode()[%q] = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestEncoder_CachesFieldPlan(t *testing.T) {
+	enc := NewEncoder("map", "")
+
+	if _, err := enc.Encode(benchA{}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	key := planCacheKey{typ: reflect.TypeOf(benchA{}), tag: "map", method: ""}
+	if _, ok := planCache.Load(key); !ok {
+		t.Fatal("expected field plan to be cached after first Encode call")
+	}
+}
+
+func TestEncoder_SharesFieldPlanAcrossInstances(t *testing.T) {
+	first := NewEncoder("map", "")
+	if _, err := first.Encode(benchA{}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	key := planCacheKey{typ: reflect.TypeOf(benchA{}), tag: "map", method: ""}
+	cached, _ := planCache.Load(key)
+
+	second := NewEncoder("map", "", WithKeyPrefix("x_"))
+	if _, err := second.Encode(benchA{}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	got, _ := planCache.Load(key)
+	if got != cached {
+		t.Fatal("expected a second Encoder with different options to reuse the same cached field plan")
+	}
+}
+
+func BenchmarkStructToMap_Nested(b *testing.B) {
+	v := benchA{AA: "aa", B: benchB{C: benchC{D: "d"}, E: "e"}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := StructToMap(v, "map", ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncoder_Encode_Nested(b *testing.B) {
+	enc := NewEncoder("map", "")
+	v := benchA{AA: "aa", B: benchB{C: benchC{D: "d"}, E: "e"}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := enc.Encode(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkStructToMap_NestedWithOptions builds a brand new Encoder on
+// every call, the same thing StructToMap(data, tag, method, opts...)
+// does whenever opts is non-empty. It should track BenchmarkEncoder_
+// Encode_Nested closely rather than re-paying the reflection/tag-parse
+// cost per call, since the field plan lives in the package-level
+// planCache shared by every Encoder for a given (tag, method, type)
+// regardless of which instance, or options, built it.
+func BenchmarkStructToMap_NestedWithOptions(b *testing.B) {
+	v := benchA{AA: "aa", B: benchB{C: benchC{D: "d"}, E: "e"}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := StructToMap(v, "map", "", WithKeyPrefix("x_")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
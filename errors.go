@@ -0,0 +1,36 @@
+package structmap
+
+import "strings"
+
+// MultiError collects multiple field-level errors produced while
+// decoding a map into a struct, so a single MapToStruct call can report
+// every failing field instead of stopping at the first one.
+type MultiError struct {
+	Errors []error
+}
+
+func (m *MultiError) add(err error) {
+	if err != nil {
+		m.Errors = append(m.Errors, err)
+	}
+}
+
+// orNil returns m as an error, or nil if no errors were collected.
+func (m *MultiError) orNil() error {
+	if len(m.Errors) == 0 {
+		return nil
+	}
+	return m
+}
+
+func (m *MultiError) Error() string {
+	msgs := make([]string, len(m.Errors))
+	for i, err := range m.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (m *MultiError) Unwrap() []error {
+	return m.Errors
+}
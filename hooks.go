@@ -0,0 +1,101 @@
+package structmap
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// EncodeHookFunc converts a value of a specific type into the
+// representation that should be stored in the result map. It is
+// consulted before the reflect.Struct branch in StructToMap/Encoder, so
+// registering a hook for a struct type such as time.Time, uuid.UUID or
+// sql.NullString keeps it from being dived into or dumped as its raw
+// fields. It is the encode-side counterpart of DecodeHookFunc.
+type EncodeHookFunc func(v reflect.Value) (interface{}, error)
+
+var globalTypeHooks sync.Map // map[reflect.Type]EncodeHookFunc
+
+// RegisterTypeHook registers fn as the package-wide EncodeHookFunc for
+// values of type t. It applies to StructToMap and to every Encoder that
+// hasn't registered its own hook for t via Encoder.RegisterTypeHook.
+func RegisterTypeHook(t reflect.Type, fn EncodeHookFunc) {
+	globalTypeHooks.Store(t, fn)
+}
+
+// RegisterTypeHook registers fn as this Encoder's EncodeHookFunc for
+// values of type t, taking precedence over any package-wide hook
+// registered with the top-level RegisterTypeHook.
+func (e *Encoder) RegisterTypeHook(t reflect.Type, fn EncodeHookFunc) {
+	e.typeHooks.Store(t, fn)
+}
+
+// lookupTypeHook returns the EncodeHookFunc for t, preferring hooks
+// registered on hooks (an Encoder's own typeHooks) over the package-wide
+// registry.
+func lookupTypeHook(hooks *sync.Map, t reflect.Type) (EncodeHookFunc, bool) {
+	if hooks != nil {
+		if v, ok := hooks.Load(t); ok {
+			return v.(EncodeHookFunc), true
+		}
+	}
+	if v, ok := globalTypeHooks.Load(t); ok {
+		return v.(EncodeHookFunc), true
+	}
+	return nil, false
+}
+
+// TimeHook returns an EncodeHookFunc that formats time.Time values with
+// layout. Register it with RegisterTypeHook(reflect.TypeOf(time.Time{}),
+// TimeHook(time.RFC3339)) to encode time.Time as an RFC3339 string
+// instead of diving into its unexported fields.
+func TimeHook(layout string) EncodeHookFunc {
+	return func(v reflect.Value) (interface{}, error) {
+		t, ok := v.Interface().(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("TimeHook: value is not a time.Time")
+		}
+		return t.Format(layout), nil
+	}
+}
+
+// StringerHook is an EncodeHookFunc that encodes any value implementing
+// fmt.Stringer by calling its String method.
+func StringerHook(v reflect.Value) (interface{}, error) {
+	s, ok := v.Interface().(fmt.Stringer)
+	if !ok {
+		return nil, fmt.Errorf("StringerHook: value does not implement fmt.Stringer")
+	}
+	return s.String(), nil
+}
+
+// TextMarshalerHook is an EncodeHookFunc that encodes any value
+// implementing encoding.TextMarshaler as a string.
+func TextMarshalerHook(v reflect.Value) (interface{}, error) {
+	m, ok := v.Interface().(encoding.TextMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("TextMarshalerHook: value does not implement encoding.TextMarshaler")
+	}
+	b, err := m.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// JSONMarshalerHook is an EncodeHookFunc that encodes any value
+// implementing json.Marshaler as its raw JSON representation.
+func JSONMarshalerHook(v reflect.Value) (interface{}, error) {
+	m, ok := v.Interface().(json.Marshaler)
+	if !ok {
+		return nil, fmt.Errorf("JSONMarshalerHook: value does not implement json.Marshaler")
+	}
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(b), nil
+}
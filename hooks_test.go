@@ -0,0 +1,44 @@
+package structmap
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type hookTarget struct {
+	CreatedAt time.Time `map:"created_at"`
+}
+
+func TestEncoder_RegisterTypeHook(t *testing.T) {
+	enc := NewEncoder("map", "")
+	enc.RegisterTypeHook(reflect.TypeOf(time.Time{}), TimeHook(time.RFC3339))
+
+	ts := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	got, err := enc.Encode(hookTarget{CreatedAt: ts})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := ts.Format(time.RFC3339)
+	if got["created_at"] != want {
+		t.Fatalf("created_at = %v, want %v", got["created_at"], want)
+	}
+}
+
+func TestRegisterTypeHook_Global(t *testing.T) {
+	typ := reflect.TypeOf(time.Time{})
+	RegisterTypeHook(typ, TimeHook(time.RFC3339))
+	defer globalTypeHooks.Delete(typ)
+
+	ts := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	got, err := StructToMap(hookTarget{CreatedAt: ts}, "map", "")
+	if err != nil {
+		t.Fatalf("StructToMap() error = %v", err)
+	}
+
+	want := ts.Format(time.RFC3339)
+	if got["created_at"] != want {
+		t.Fatalf("created_at = %v, want %v", got["created_at"], want)
+	}
+}
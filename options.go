@@ -0,0 +1,58 @@
+package structmap
+
+// Options holds the configuration shared by StructToMap, Encoder and
+// MapToStruct. It is built from a list of Option funcs and should not be
+// constructed directly outside the package.
+type Options struct {
+	weaklyTyped  bool
+	decodeHooks  []DecodeHookFunc
+	keyTransform func(string) string
+	keyPrefix    string
+}
+
+// Option configures encoding/decoding behavior for StructToMap, Encoder
+// and MapToStruct.
+type Option func(*Options)
+
+// WeaklyTypedInput enables best-effort conversions between compatible
+// kinds during MapToStruct, e.g. string<->number, string<->bool and
+// numeric widening/narrowing.
+func WeaklyTypedInput() Option {
+	return func(o *Options) {
+		o.weaklyTyped = true
+	}
+}
+
+// WithDecodeHook registers a DecodeHookFunc consulted before a source
+// value is assigned to a destination field, letting callers customize
+// how the value is converted into the field's type.
+func WithDecodeHook(fn DecodeHookFunc) Option {
+	return func(o *Options) {
+		o.decodeHooks = append(o.decodeHooks, fn)
+	}
+}
+
+// WithKeyTransform applies fn to every key StructToMap or Encoder.Encode
+// produces, including dotted path segments and dive-produced keys.
+func WithKeyTransform(fn func(string) string) Option {
+	return func(o *Options) {
+		o.keyTransform = fn
+	}
+}
+
+// WithKeyPrefix prefixes every top-level key StructToMap or
+// Encoder.Encode produces with prefix, so a dived or dotted-encoded
+// struct can be namespaced without editing its tags.
+func WithKeyPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.keyPrefix = prefix
+	}
+}
+
+func applyOptions(opts []Option) *Options {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -63,95 +63,15 @@ const (
 //
 //	Result:
 //	map[aa:string b.c:string]
-func StructToMap(data interface{}, tag string, method string) (MappedStruct, error) {
-	result := make(MappedStruct)
-	reflectedValue := reflect.ValueOf(data)
-
-	if reflectedValue.Kind() == reflect.Pointer {
-		if reflectedValue.IsNil() {
-			return nil, fmt.Errorf("%s is a nil pointer", reflectedValue.Kind().String())
-		}
-		reflectedValue = reflectedValue.Elem()
-	}
-	if reflectedValue.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("data is not a struct but %s", reflectedValue.Kind().String())
-	}
-
-	reflectType := reflectedValue.Type()
-	for i := 0; i < reflectType.NumField(); i++ {
-		fieldType := reflectType.Field(i)
-
-		// ignore unexported field
-		if fieldType.PkgPath != "" {
-			continue
-		}
-
-		tagVal, flag := tagsReader(fieldType, tag)
-		if flag&FLAG_IGNORE != 0 {
-			continue
-		}
-
-		fieldValue := reflectedValue.Field(i)
-		if flag&FLAG_OMITEMPTY != 0 && fieldValue.IsZero() {
-			continue
-		}
-		if fieldValue.Kind() == reflect.Pointer {
-			if fieldValue.IsNil() {
-				continue
-			}
-			fieldValue = fieldValue.Elem()
-		}
-
-		key, value, err := assignValueWithMethod(fieldValue, method)
-		if err != nil {
-			return nil, err
-		}
-		if key != "" {
-			result[key] = value
-			continue
-		}
-
-		switch fieldValue.Kind() {
-		case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
-			result[tagVal] = fieldValue
-		case reflect.Struct:
-			deepRes, deepErr := StructToMap(fieldValue.Interface(), tag, method)
-			if deepErr != nil {
-				return nil, deepErr
-			}
-			if flag&FLAG_DIVE != 0 {
-				for k, v := range deepRes {
-					result[k] = v
-				}
-			} else if flag&FLAG_DOTTED != 0 {
-				for k, v := range deepRes {
-					result[tagVal+"."+k] = v
-				}
-			} else {
-				result[tagVal] = deepRes
-			}
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			result[tagVal] = fieldValue.Int()
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			result[tagVal] = fieldValue.Uint()
-		case reflect.Float32, reflect.Float64:
-			result[tagVal] = fieldValue.Float()
-		case reflect.String:
-			if flag&FLAG_WILDCARD != 0 {
-				result[tagVal] = "%" + fieldValue.String() + "%"
-			} else {
-				result[tagVal] = fieldValue.String()
-			}
-		case reflect.Bool:
-			result[tagVal] = fieldValue.Bool()
-		case reflect.Complex64, reflect.Complex128:
-			result[tagVal] = fieldValue.Complex()
-		case reflect.Interface:
-			result[tagVal] = fieldValue.Interface()
-		}
+//
+// Pass WithKeyTransform to rename every produced key (e.g. ToSnakeCase,
+// ToCamelCase, ToKebabCase) and WithKeyPrefix to namespace the whole
+// result without editing tags.
+func StructToMap(data interface{}, tag string, method string, opts ...Option) (MappedStruct, error) {
+	if len(opts) == 0 {
+		return defaultEncoderFor(tag, method).Encode(data)
 	}
-
-	return result, nil
+	return NewEncoder(tag, method, opts...).Encode(data)
 }
 
 // tagsReader read tag with format `json:"name,omitempty"` or `json:"-"`
@@ -0,0 +1,74 @@
+package structmap
+
+import (
+	"reflect"
+	"testing"
+)
+
+type sliceMapItem struct {
+	Name string `map:"name"`
+}
+
+type sliceMapTarget struct {
+	Items  []sliceMapItem          `map:"items"`
+	Tags   []string                `map:"tags"`
+	ByName map[string]sliceMapItem `map:"by_name"`
+	Scores map[string]int          `map:"scores"`
+}
+
+func TestStructToMap_SliceOfStructs(t *testing.T) {
+	src := sliceMapTarget{
+		Items: []sliceMapItem{{Name: "a"}, {Name: "b"}},
+		Tags:  []string{"x", "y"},
+	}
+
+	got, err := StructToMap(src, "map", "")
+	if err != nil {
+		t.Fatalf("StructToMap() error = %v", err)
+	}
+
+	items, ok := got["items"].([]MappedStruct)
+	if !ok {
+		t.Fatalf("items = %T, want []MappedStruct", got["items"])
+	}
+	want := []MappedStruct{{"name": "a"}, {"name": "b"}}
+	if !reflect.DeepEqual(items, want) {
+		t.Fatalf("items = %+v, want %+v", items, want)
+	}
+
+	tags, ok := got["tags"].([]interface{})
+	if !ok {
+		t.Fatalf("tags = %T, want []interface{}", got["tags"])
+	}
+	if !reflect.DeepEqual(tags, []interface{}{"x", "y"}) {
+		t.Fatalf("tags = %+v", tags)
+	}
+}
+
+func TestStructToMap_MapOfStructs(t *testing.T) {
+	src := sliceMapTarget{
+		ByName: map[string]sliceMapItem{"first": {Name: "a"}},
+		Scores: map[string]int{"first": 1},
+	}
+
+	got, err := StructToMap(src, "map", "")
+	if err != nil {
+		t.Fatalf("StructToMap() error = %v", err)
+	}
+
+	byName, ok := got["by_name"].(map[string]MappedStruct)
+	if !ok {
+		t.Fatalf("by_name = %T, want map[string]MappedStruct", got["by_name"])
+	}
+	if !reflect.DeepEqual(byName, map[string]MappedStruct{"first": {"name": "a"}}) {
+		t.Fatalf("by_name = %+v", byName)
+	}
+
+	scores, ok := got["scores"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("scores = %T, want map[string]interface{}", got["scores"])
+	}
+	if !reflect.DeepEqual(scores, map[string]interface{}{"first": 1}) {
+		t.Fatalf("scores = %+v", scores)
+	}
+}